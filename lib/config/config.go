@@ -0,0 +1,76 @@
+package config
+
+// Configuration is the msh config file (msh-config.json) unmarshaled in memory.
+type Configuration struct {
+	Server ConfigServer
+	Msh    ConfigMsh
+}
+
+// ConfigServer holds the wrapped minecraft server's settings.
+type ConfigServer struct {
+	Folder   string
+	FileName string
+	Version  string
+	Protocol int
+}
+
+// ConfigMsh holds msh's own settings.
+type ConfigMsh struct {
+	ID           string
+	ListenPort   int
+	Whitelist    []string
+	AllowSuspend bool
+
+	// MetricsEnabled gates the /metrics exporter (lib/metrics): when false no
+	// listener is bound and no collector work happens on scrape.
+	MetricsEnabled bool
+	// MetricsListen is the address (host:port) the /metrics endpoint binds to.
+	MetricsListen string
+
+	// MemoryLimitMB and CpuRateLimitPercent cap the server process tree on
+	// platforms that can enforce it (windows, via a Job Object - see
+	// lib/servctrl/procgroup.go). 0 means unlimited.
+	MemoryLimitMB       int
+	CpuRateLimitPercent int
+
+	// MshcentralURL is where the primary (mshcentral) telemetry sink posts
+	// Api2Req segment reports.
+	MshcentralURL string
+
+	// TelemetryInflux* configure the optional InfluxDB line-protocol sink;
+	// it is only added to progmgr's sink list when TelemetryInfluxURL is set.
+	TelemetryInfluxURL    string
+	TelemetryInfluxOrg    string
+	TelemetryInfluxBucket string
+	TelemetryInfluxToken  string
+
+	// TelemetryOtlpURL configures the optional OTLP/HTTP metrics sink; it is
+	// only added to progmgr's sink list when set.
+	TelemetryOtlpURL string
+
+	// TelemetryDryRun adds a sink that only logs the marshaled payload at
+	// LVL_D, for developing against buildApi2Req without touching the network.
+	TelemetryDryRun bool
+}
+
+// ConfigRuntime is the config currently loaded and in use.
+var ConfigRuntime Configuration
+
+// configDefaultSave is set when a config value had to be defaulted/regenerated
+// (e.g. a missing/invalid mshid), so the config file gets rewritten with it.
+var configDefaultSave bool
+
+// Javav is the java version string detected at startup.
+var Javav string
+
+// network config derived from ConfigRuntime and server.properties at startup.
+var (
+	ListenHost, TargetHost string
+	ListenPort, TargetPort int
+)
+
+// ServerIcon is the base64-encoded server-list icon currently in use;
+// defaultServerIcon is the fallback loaded when no user icon is found.
+var (
+	ServerIcon, defaultServerIcon string
+)
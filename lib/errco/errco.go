@@ -0,0 +1,80 @@
+// Package errco defines the error type and log levels shared by every msh
+// package: an *Error carries an error code, a log level and a trace of the
+// functions it passed through, so a log line points at the exact call chain
+// that produced it.
+package errco
+
+import "fmt"
+
+// log levels, from least to most verbose.
+const (
+	LVL_1 = iota // important, always logged
+	LVL_2
+	LVL_3 // informational
+	LVL_D // debug
+	LVL_E // extended debug (full request/response bodies, ...)
+)
+
+// terminal colors used to highlight specific log lines.
+const (
+	COLOR_RESET  = "\033[0m"
+	COLOR_RED    = "\033[31m"
+	COLOR_YELLOW = "\033[33m"
+	COLOR_PURPLE = "\033[35m"
+)
+
+// error codes. New codes are appended at the end so existing values never
+// shift.
+const (
+	ERROR_CONFIG_LOAD = iota
+	ERROR_CONFIG_CHECK
+	ERROR_CONVERSION
+	ERROR_ICON_LOAD
+	ERROR_VERSION_LOAD
+	ERROR_VERSION
+	ERROR_PLAYER_NOT_IN_WHITELIST
+	ERROR_GET_CPU_INFO
+	ERROR_GET_CORES
+	ERROR_GET_MEMORY
+	ERROR_METRICS_LISTEN
+	ERROR_SERVER_NOT_RESPONDING
+)
+
+// Error is the error type passed around msh instead of the stdlib error,
+// so a log level and a function trace travel with it.
+type Error struct {
+	Code  int
+	Lvl   int
+	Trace string
+	Str   string
+}
+
+// NewErr builds a new *Error originating at function tag.
+func NewErr(code int, lvl int, tag string, str string) *Error {
+	return &Error{Code: code, Lvl: lvl, Trace: tag, Str: str}
+}
+
+// AddTrace prepends tag to the error trace and returns the same *Error, so
+// callers can do `return errMsh.AddTrace("callerFunc")`.
+func (e *Error) AddTrace(tag string) *Error {
+	e.Trace = fmt.Sprintf("%s -> %s", tag, e.Trace)
+	return e
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("[%d] %s: %s", e.Code, e.Trace, e.Str)
+}
+
+// LogMshErr logs an *Error at its own level.
+func LogMshErr(e *Error) {
+	if e == nil {
+		return
+	}
+	Logln(e.Lvl, "%s", e.Error())
+}
+
+// Logln prints a formatted log line. lvl is not used for filtering: every
+// line is printed, whatever its level.
+func Logln(lvl int, format string, a ...interface{}) {
+	fmt.Printf(format+"\n", a...)
+}
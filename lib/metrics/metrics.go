@@ -0,0 +1,42 @@
+// Package metrics exposes a Prometheus/OpenMetrics HTTP endpoint for msh.
+// It only knows how to serve a registry on a listen address: the actual
+// gauges/counters are supplied by the caller (see progmgr.mshCollector),
+// so this package has no knowledge of msh internals.
+package metrics
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"msh/lib/errco"
+)
+
+// Listen starts (in a new goroutine) an HTTP server serving OpenMetrics/Prometheus
+// text format on addr at "/metrics", exposing the collectors registered in reg.
+// It returns as soon as the listener is bound, so that a bind failure (e.g.
+// address already in use) can be reported to the caller instead of being lost
+// in a background goroutine.
+func Listen(addr string, reg *prometheus.Registry) *errco.Error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+
+	server := &http.Server{Addr: addr, Handler: mux}
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return errco.NewErr(errco.ERROR_METRICS_LISTEN, errco.LVL_1, "Listen", err.Error())
+	}
+
+	go func() {
+		if err := server.Serve(ln); err != nil && err != http.ErrServerClosed {
+			errco.LogMshErr(errco.NewErr(errco.ERROR_METRICS_LISTEN, errco.LVL_1, "Listen", err.Error()))
+		}
+	}()
+
+	errco.Logln(errco.LVL_1, "metrics: serving on %s/metrics", addr)
+
+	return nil
+}
@@ -0,0 +1,43 @@
+// Package procgroup tracks the minecraft server process and every child it
+// spawns as a single accounting/limiting unit.
+//
+// On windows this is backed by a Job Object (see procgroup_windows.go): the
+// server process is assigned to the job at start, so short-lived children
+// that come and go between two gopsutil snapshots are never missed, limits
+// are enforced by the OS instead of polling, and closing the job kills the
+// whole tree atomically. On every other platform (procgroup_other.go) it
+// falls back to the pre-existing gopsutil process-tree walk.
+package procgroup
+
+// Limits caps the resources a Group is allowed to use. A zero value for a
+// field means "no limit". Limits that the current platform cannot enforce
+// are silently ignored (see procgroup_other.go).
+type Limits struct {
+	MemoryLimitMB       int // committed-memory cap in MB for the whole group, 0 = unlimited
+	CpuRateLimitPercent int // cpu rate cap in percent of total machine cpu capacity, 0 = unlimited
+}
+
+// Stats reports aggregate resource usage for a Group.
+type Stats struct {
+	CpuPercent float64
+	MemPercent float64
+}
+
+// Group tracks the resource usage of a process and everything it spawns,
+// and optionally enforces Limits on it.
+type Group interface {
+	// Assign adds pid (and, on platforms that support it, any future
+	// children spawned by pid) to the group.
+	Assign(pid int) error
+
+	// Stats returns the current aggregate cpu/mem usage for the group.
+	Stats() (Stats, error)
+
+	// Terminate kills every process currently in the group.
+	Terminate() error
+
+	// Close releases the group. On windows the job is created with
+	// JOB_OBJECT_LIMIT_KILL_ON_JOB_CLOSE, so closing it also kills every
+	// process still in it; on other platforms the processes keep running.
+	Close() error
+}
@@ -0,0 +1,85 @@
+//go:build !windows
+
+package procgroup
+
+import (
+	"github.com/shirou/gopsutil/process"
+)
+
+// gopsutilGroup is the non-windows Group fallback: it walks proc.Children()
+// recursively on every Stats() call, as progmgr's own tree walk used to.
+// Limits are not enforced here; there is no portable equivalent of a Job
+// Object, so a non-zero Limits is accepted but silently ignored.
+type gopsutilGroup struct {
+	pid int32
+}
+
+// New returns a Group that walks the process tree with gopsutil on demand.
+// limits is ignored on this platform.
+func New(name string, limits Limits) (Group, error) {
+	return &gopsutilGroup{}, nil
+}
+
+func (g *gopsutilGroup) Assign(pid int) error {
+	g.pid = int32(pid)
+	return nil
+}
+
+func (g *gopsutilGroup) Stats() (Stats, error) {
+	proc, err := process.NewProcess(g.pid)
+	if err != nil {
+		return Stats{}, err
+	}
+
+	var cpu, mem float64
+	for _, p := range treeProc(proc) {
+		pCpu, err := p.CPUPercent()
+		if err != nil {
+			return Stats{}, err
+		}
+		pMem, err := p.MemoryPercent()
+		if err != nil {
+			return Stats{}, err
+		}
+		cpu += float64(pCpu)
+		mem += float64(pMem)
+	}
+
+	return Stats{CpuPercent: cpu, MemPercent: mem}, nil
+}
+
+func (g *gopsutilGroup) Terminate() error {
+	proc, err := process.NewProcess(g.pid)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range treeProc(proc) {
+		_ = p.Kill()
+	}
+
+	return nil
+}
+
+func (g *gopsutilGroup) Close() error {
+	return nil
+}
+
+// treeProc returns the list of tree pids (with ppid). On linux, a leaf
+// process makes Children() return a "process does not have children" error,
+// which is not a real failure.
+func treeProc(proc *process.Process) []*process.Process {
+	children, err := proc.Children()
+	if err != nil {
+		if err.Error() != "process does not have children" {
+			return []*process.Process{proc}
+		}
+		return []*process.Process{proc}
+	}
+
+	tree := []*process.Process{proc}
+	for _, child := range children {
+		tree = append(tree, treeProc(child)...)
+	}
+	return tree
+}
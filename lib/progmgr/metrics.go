@@ -0,0 +1,76 @@
+package progmgr
+
+import (
+	"runtime"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"msh/lib/config"
+	"msh/lib/errco"
+	"msh/lib/metrics"
+	"msh/lib/servctrl"
+)
+
+// descriptors for the metrics exposed by mshCollector.
+var (
+	descUptime         = prometheus.NewDesc("msh_uptime_seconds", "seconds since msh startup", nil, nil)
+	descHibeSeconds    = prometheus.NewDesc("msh_hibernate_seconds_total", "seconds spent hibernated in the current segment", nil, nil)
+	descTreeCpuPercent = prometheus.NewDesc("msh_tree_cpu_percent", "cpu usage percent summed over the minecraft server process tree", nil, nil)
+	descTreeMemPercent = prometheus.NewDesc("msh_tree_mem_percent", "memory usage percent summed over the minecraft server process tree", nil, nil)
+	descPlayerSeconds  = prometheus.NewDesc("msh_player_seconds_total", "cumulative player-seconds in the current segment", nil, nil)
+	descPlayerCount    = prometheus.NewDesc("msh_player_count", "players currently connected", nil, nil)
+	descFrozen         = prometheus.NewDesc("msh_server_frozen", "1 if the minecraft server is frozen/hibernated, 0 if warm", nil, nil)
+	descInfo           = prometheus.NewDesc("msh_info", "msh build and machine info", []string{"mshv", "os", "arch", "msv"}, nil)
+)
+
+// mshCollector implements prometheus.Collector, deriving its samples from the
+// same state buildApi2Req and getMshTreeStats already gather. Unlike the
+// 4-second polling loop that feeds buildApi2Req, mshCollector only does work
+// when it is actually scraped.
+type mshCollector struct{}
+
+func (mshCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- descUptime
+	ch <- descHibeSeconds
+	ch <- descTreeCpuPercent
+	ch <- descTreeMemPercent
+	ch <- descPlayerSeconds
+	ch <- descPlayerCount
+	ch <- descFrozen
+	ch <- descInfo
+}
+
+func (mshCollector) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(descUptime, prometheus.CounterValue, time.Since(msh.startTime).Seconds())
+	ch <- prometheus.MustNewConstMetric(descHibeSeconds, prometheus.CounterValue, float64(sgm.stats.secondsHibe))
+	ch <- prometheus.MustNewConstMetric(descPlayerSeconds, prometheus.CounterValue, float64(sgm.stats.playerSec))
+	ch <- prometheus.MustNewConstMetric(descPlayerCount, prometheus.GaugeValue, float64(servctrl.CountPlayers()))
+
+	treeCpu, treeMem := getMshTreeStats()
+	ch <- prometheus.MustNewConstMetric(descTreeCpuPercent, prometheus.GaugeValue, treeCpu)
+	ch <- prometheus.MustNewConstMetric(descTreeMemPercent, prometheus.GaugeValue, treeMem)
+
+	frozen := 0.0
+	if servctrl.IsServerFrozen() {
+		frozen = 1
+	}
+	ch <- prometheus.MustNewConstMetric(descFrozen, prometheus.GaugeValue, frozen)
+
+	ch <- prometheus.MustNewConstMetric(descInfo, prometheus.GaugeValue, 1,
+		MshVersion, runtime.GOOS, runtime.GOARCH, config.ConfigRuntime.Server.Version)
+}
+
+// MetricsMgr starts the /metrics endpoint if enabled in config.
+// It is a no-op (and binds no port) when Msh.MetricsEnabled is false, so
+// users who don't want the exporter pay nothing for it.
+func MetricsMgr() *errco.Error {
+	if !config.ConfigRuntime.Msh.MetricsEnabled {
+		return nil
+	}
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(mshCollector{})
+
+	return metrics.Listen(config.ConfigRuntime.Msh.MetricsListen, reg)
+}
@@ -0,0 +1,65 @@
+package progmgr
+
+import (
+	"time"
+
+	"msh/lib/errco"
+)
+
+// MshVersion and protv identify this msh build in outgoing telemetry.
+const (
+	MshVersion = "v2.6.0"
+	protv      = 2
+)
+
+// mshStruct tracks msh-wide runtime state.
+type mshStruct struct {
+	startTime time.Time
+}
+
+var msh mshStruct
+
+// segment tracks usage stats accumulated since the last segment report.
+type segment struct {
+	stats struct {
+		seconds     int
+		secondsHibe int
+		cpuUsage    float64
+		memUsage    float64
+		playerSec   int
+	}
+}
+
+var sgm segment
+
+// ReqSent is signaled by the primary telemetry sink once it has attempted to
+// deliver a segment report, so callers waiting on request completion (e.g. a
+// graceful-shutdown path that wants the last report flushed) can proceed.
+var ReqSent chan bool = make(chan bool, 1)
+
+// MshMgr starts msh's background managers: the /metrics exporter and the
+// telemetry sinks/segment report loop.
+func MshMgr() *errco.Error {
+	msh.startTime = time.Now()
+
+	if errMsh := MetricsMgr(); errMsh != nil {
+		errco.LogMshErr(errMsh.AddTrace("MshMgr"))
+	}
+
+	initTelemetry()
+
+	go segmentReportLoop()
+
+	return nil
+}
+
+// segmentReportLoop periodically builds and dispatches a segment report,
+// same cadence as the previous direct-POST implementation.
+func segmentReportLoop() {
+	ticker := time.NewTicker(4 * time.Second)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		dispatchApi2Req(buildApi2Req(false))
+	}
+}
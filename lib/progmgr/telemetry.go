@@ -0,0 +1,298 @@
+package progmgr
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"msh/lib/config"
+	"msh/lib/errco"
+	"msh/lib/model"
+)
+
+// telemetryRingSize caps how many undelivered samples a sink buffers before
+// the oldest ones are dropped in favor of the newest.
+const telemetryRingSize = 32
+
+// retry backoff bounds for a sink that keeps failing.
+const (
+	telemetryRetryBaseDelay = 2 * time.Second
+	telemetryRetryMaxDelay  = 2 * time.Minute
+)
+
+// TelemetrySink is one destination a model.Api2Req sample can be sent to.
+// Send should be quick to fail (the caller is not expected to block on a
+// slow/unreachable endpoint): sinkState handles buffering/retry around it.
+type TelemetrySink interface {
+	Name() string
+	Send(api2req *model.Api2Req) *errco.Error
+}
+
+// sinkState wraps a TelemetrySink with a ring buffer and a single worker
+// goroutine that is the only thing ever allowed to call sink.Send. Serializing
+// every delivery (fresh samples and retries alike) through one goroutine means
+// a slow/failing sink can never be sent to concurrently and samples are
+// always delivered oldest-first, in the order they were produced.
+type sinkState struct {
+	sink TelemetrySink
+	wake chan struct{}
+
+	mu      sync.Mutex
+	ring    []*model.Api2Req
+	backoff time.Duration
+}
+
+func newSinkState(sink TelemetrySink) *sinkState {
+	st := &sinkState{sink: sink, backoff: telemetryRetryBaseDelay, wake: make(chan struct{}, 1)}
+	go st.worker()
+	return st
+}
+
+// submit queues req for delivery, dropping the oldest sample once
+// telemetryRingSize is exceeded, and wakes the worker if it's idle.
+func (st *sinkState) submit(req *model.Api2Req) {
+	st.mu.Lock()
+	st.ring = append(st.ring, req)
+	if len(st.ring) > telemetryRingSize {
+		st.ring = st.ring[len(st.ring)-telemetryRingSize:]
+	}
+	st.mu.Unlock()
+
+	select {
+	case st.wake <- struct{}{}:
+	default:
+	}
+}
+
+// worker drains the ring oldest-first, retrying the head of the ring with
+// exponential backoff until it succeeds before moving on to the next sample -
+// this is what keeps delivery both serialized and in order.
+func (st *sinkState) worker() {
+	for range st.wake {
+		for {
+			st.mu.Lock()
+			if len(st.ring) == 0 {
+				st.mu.Unlock()
+				break
+			}
+			req := st.ring[0]
+			st.mu.Unlock()
+
+			if errMsh := st.sink.Send(req); errMsh != nil {
+				errco.LogMshErr(errMsh.AddTrace("sinkState.worker " + st.sink.Name()))
+
+				st.mu.Lock()
+				delay := st.backoff
+				st.backoff *= 2
+				if st.backoff > telemetryRetryMaxDelay {
+					st.backoff = telemetryRetryMaxDelay
+				}
+				st.mu.Unlock()
+
+				time.Sleep(delay)
+				continue
+			}
+
+			st.mu.Lock()
+			// submit may have trimmed req off the ring while Send ran; only
+			// pop it if it is still the head, so no unsent sample is lost.
+			if len(st.ring) > 0 && st.ring[0] == req {
+				st.ring = st.ring[1:]
+			}
+			st.backoff = telemetryRetryBaseDelay
+			st.mu.Unlock()
+		}
+	}
+}
+
+// telemetrySinks holds the enabled sink states, built once from config by
+// initTelemetry.
+var telemetrySinks []*sinkState
+
+// initTelemetry builds telemetrySinks from config.ConfigRuntime.Msh. mshcentral
+// is always the primary sink (its ReqSent signaling is relied upon by existing
+// callers); influxdb/otlp/dry-run are opt-in.
+func initTelemetry() {
+	telemetrySinks = []*sinkState{newSinkState(&mshcentralSink{url: config.ConfigRuntime.Msh.MshcentralURL})}
+
+	if config.ConfigRuntime.Msh.TelemetryInfluxURL != "" {
+		telemetrySinks = append(telemetrySinks, newSinkState(&influxSink{
+			url:    config.ConfigRuntime.Msh.TelemetryInfluxURL,
+			org:    config.ConfigRuntime.Msh.TelemetryInfluxOrg,
+			bucket: config.ConfigRuntime.Msh.TelemetryInfluxBucket,
+			token:  config.ConfigRuntime.Msh.TelemetryInfluxToken,
+		}))
+	}
+
+	if config.ConfigRuntime.Msh.TelemetryOtlpURL != "" {
+		telemetrySinks = append(telemetrySinks, newSinkState(&otlpSink{url: config.ConfigRuntime.Msh.TelemetryOtlpURL}))
+	}
+
+	if config.ConfigRuntime.Msh.TelemetryDryRun {
+		telemetrySinks = append(telemetrySinks, newSinkState(dryRunSink{}))
+	}
+}
+
+// dispatchApi2Req fans api2req out to every enabled sink. Each sink has its
+// own worker goroutine buffering and retrying independently, so a
+// slow/unreachable sink never blocks or drops the sample for the others.
+func dispatchApi2Req(api2req *model.Api2Req) {
+	for _, st := range telemetrySinks {
+		st.submit(api2req)
+	}
+}
+
+// mshcentralSink is the original telemetry destination: an Api2Req POSTed to
+// mshcentral, with the server's Api2Res read back. Its Send preserves the
+// pre-refactor ReqSent signaling so existing callers waiting on request
+// completion see no behavior change.
+type mshcentralSink struct {
+	url string
+}
+
+func (mshcentralSink) Name() string { return "mshcentral" }
+
+func (s *mshcentralSink) Send(api2req *model.Api2Req) *errco.Error {
+	defer func() {
+		select {
+		case ReqSent <- true:
+		default:
+		}
+	}()
+
+	res, errMsh := sendApi2Req(s.url, api2req)
+	if errMsh != nil {
+		return errMsh.AddTrace("mshcentralSink.Send")
+	}
+
+	resJson, errMsh := readApi2Res(res)
+	if errMsh != nil {
+		return errMsh.AddTrace("mshcentralSink.Send")
+	}
+
+	errco.Logln(errco.LVL_D, "mshcentralSink: response received: %v", resJson)
+
+	return nil
+}
+
+// influxSink writes a sample as an InfluxDB line-protocol point to an
+// InfluxDB v2 /api/v2/write endpoint.
+type influxSink struct {
+	url, org, bucket, token string
+}
+
+func (influxSink) Name() string { return "influxdb" }
+
+func (s *influxSink) Send(api2req *model.Api2Req) *errco.Error {
+	line := fmt.Sprintf(
+		"msh,mshid=%s,os=%s uptime=%di,secondsHibe=%di,cpuUsage=%f,memUsage=%f,playerSec=%di\n",
+		api2req.Msh.ID, api2req.Machine.Os,
+		api2req.Msh.Uptime, api2req.Msh.Sgm.SecondsHibe,
+		api2req.Msh.Sgm.CpuUsage, api2req.Msh.Sgm.MemUsage,
+		api2req.Msh.Sgm.PlayerSec,
+	)
+
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s?org=%s&bucket=%s&precision=s", s.url, s.org, s.bucket), bytes.NewReader([]byte(line)))
+	if err != nil {
+		return errco.NewErr(errco.ERROR_VERSION, errco.LVL_D, "influxSink.Send", err.Error())
+	}
+	req.Header.Set("Authorization", "Token "+s.token)
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+
+	client := &http.Client{Timeout: 4 * time.Second}
+	res, err := client.Do(req)
+	if err != nil {
+		return errco.NewErr(errco.ERROR_VERSION, errco.LVL_D, "influxSink.Send", err.Error())
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		return errco.NewErr(errco.ERROR_VERSION, errco.LVL_D, "influxSink.Send", fmt.Sprintf("influxdb write failed: %s", res.Status))
+	}
+
+	return nil
+}
+
+// otlpSink writes a sample as an OTLP/HTTP metrics export request.
+type otlpSink struct {
+	url string
+}
+
+func (otlpSink) Name() string { return "otlp" }
+
+func (s *otlpSink) Send(api2req *model.Api2Req) *errco.Error {
+	// minimal OTLP/HTTP JSON encoding (gauges only): full protobuf encoding
+	// is left to a proper OTLP exporter library if/when one is vendored.
+	payload := map[string]interface{}{
+		"resourceMetrics": []map[string]interface{}{{
+			"resource": map[string]interface{}{
+				"attributes": []map[string]interface{}{
+					{"key": "mshid", "value": map[string]string{"stringValue": api2req.Msh.ID}},
+				},
+			},
+			"scopeMetrics": []map[string]interface{}{{
+				"metrics": []map[string]interface{}{
+					otlpGauge("msh.uptime", float64(api2req.Msh.Uptime)),
+					otlpGauge("msh.hibernate_seconds", float64(api2req.Msh.Sgm.SecondsHibe)),
+					otlpGauge("msh.player_seconds", float64(api2req.Msh.Sgm.PlayerSec)),
+				},
+			}},
+		}},
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return errco.NewErr(errco.ERROR_VERSION, errco.LVL_D, "otlpSink.Send", err.Error())
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return errco.NewErr(errco.ERROR_VERSION, errco.LVL_D, "otlpSink.Send", err.Error())
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: 4 * time.Second}
+	res, err := client.Do(req)
+	if err != nil {
+		return errco.NewErr(errco.ERROR_VERSION, errco.LVL_D, "otlpSink.Send", err.Error())
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		return errco.NewErr(errco.ERROR_VERSION, errco.LVL_D, "otlpSink.Send", fmt.Sprintf("otlp export failed: %s", res.Status))
+	}
+
+	return nil
+}
+
+func otlpGauge(name string, value float64) map[string]interface{} {
+	return map[string]interface{}{
+		"name": name,
+		"gauge": map[string]interface{}{
+			"dataPoints": []map[string]interface{}{{
+				"asDouble":     value,
+				"timeUnixNano": fmt.Sprintf("%d", time.Now().UnixNano()),
+			}},
+		},
+	}
+}
+
+// dryRunSink just logs the marshaled payload at LVL_D, for developing against
+// buildApi2Req without touching the network.
+type dryRunSink struct{}
+
+func (dryRunSink) Name() string { return "dry-run" }
+
+func (dryRunSink) Send(api2req *model.Api2Req) *errco.Error {
+	reqByte, err := json.Marshal(api2req)
+	if err != nil {
+		return errco.NewErr(errco.ERROR_VERSION, errco.LVL_D, "dryRunSink.Send", err.Error())
+	}
+
+	errco.Logln(errco.LVL_D, "dry-run sink: %s", string(reqByte))
+
+	return nil
+}
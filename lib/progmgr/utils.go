@@ -6,13 +6,11 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
-	"os"
 	"runtime"
 	"time"
 
 	"github.com/shirou/gopsutil/cpu"
 	"github.com/shirou/gopsutil/mem"
-	"github.com/shirou/gopsutil/process"
 
 	"msh/lib/config"
 	"msh/lib/errco"
@@ -79,16 +77,10 @@ func buildApi2Req(preTerm bool) *model.Api2Req {
 	return reqJson
 }
 
-// sendApi2Req sends api2 request
+// sendApi2Req posts api2req to url and returns the raw http response. It is
+// the low-level transport used by mshcentralSink; ReqSent signaling and
+// retry/buffering now live in telemetry.go, one level up.
 func sendApi2Req(url string, api2req *model.Api2Req) (*http.Response, *errco.Error) {
-	// before returning, communicate that request has been sent
-	defer func() {
-		select {
-		case ReqSent <- true:
-		default:
-		}
-	}()
-
 	errco.Logln(errco.LVL_D, "sendApi2Req: sending request...")
 
 	// marshal request struct
@@ -141,46 +133,18 @@ func readApi2Res(res *http.Response) (*model.Api2Res, *errco.Error) {
 	return resJson, nil
 }
 
-// getMshTreeStats returns current msh tree cpu/mem usage
+// getMshTreeStats returns current minecraft server process tree cpu/mem usage
+// (the msh process itself is not included). The actual
+// tracking (Job Object on windows, gopsutil process-tree walk elsewhere) is
+// owned by servctrl, which is also what starts/stops the server process -
+// see lib/servctrl/procgroup.go.
 func getMshTreeStats() (float64, float64) {
-	var mshTreeCpu, mshTreeMem float64 = 0, 0
-
-	if mshProc, err := process.NewProcess(int32(os.Getpid())); err != nil {
+	cpuPercent, memPercent, errMsh := servctrl.TreeStats()
+	if errMsh != nil {
+		errco.LogMshErr(errMsh.AddTrace("getMshTreeStats"))
 		// return current avg usage in case of error
 		return sgm.stats.cpuUsage, sgm.stats.memUsage
-	} else {
-		for _, c := range treeProc(mshProc) {
-			if pCpu, err := c.CPUPercent(); err != nil {
-				// return current avg usage in case of error
-				return sgm.stats.cpuUsage, sgm.stats.memUsage
-			} else if pMem, err := c.MemoryPercent(); err != nil {
-				// return current avg usage in case of error
-				return sgm.stats.cpuUsage, sgm.stats.memUsage
-			} else {
-				mshTreeCpu += float64(pCpu)
-				mshTreeMem += float64(pMem)
-			}
-		}
-	}
-
-	return mshTreeCpu, mshTreeMem
-}
-
-// treeProc returns the list of tree pids (with ppid)
-func treeProc(proc *process.Process) []*process.Process {
-	children, err := proc.Children()
-	if err != nil {
-		// on linux, if a process does not have children an error is returned
-		if err.Error() != "process does not have children" {
-			return []*process.Process{proc}
-		}
-		// return pid -1 to indicate that an error occurred
-		return []*process.Process{{Pid: -1}}
 	}
 
-	tree := []*process.Process{proc}
-	for _, child := range children {
-		tree = append(tree, treeProc(child)...)
-	}
-	return tree
+	return cpuPercent, memPercent
 }
\ No newline at end of file
@@ -0,0 +1,162 @@
+// Package servctrl owns the minecraft server process: starting it, stopping
+// it and reporting its resource usage and player count to progmgr.
+package servctrl
+
+import (
+	"os/exec"
+	"sync"
+	"time"
+
+	"msh/lib/config"
+	"msh/lib/errco"
+	"msh/lib/procgroup"
+)
+
+var (
+	mu        sync.Mutex
+	cmd       *exec.Cmd
+	startTime time.Time
+	tree      procgroup.Group
+	players   = map[string]struct{}{}
+	// frozen starts true: with no StartMS call yet there is no warm server to
+	// route connections to, which is exactly what "frozen" means.
+	frozen = true
+)
+
+// StartMS launches the minecraft server process and assigns it (and, on
+// platforms that support it, every child it spawns) to a procgroup, so
+// resource accounting/limits and shutdown no longer depend on a best-effort
+// process-tree snapshot.
+func StartMS() *errco.Error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	c := exec.Command("java", "-jar", config.ConfigRuntime.Server.FileName, "nogui")
+	c.Dir = config.ConfigRuntime.Server.Folder
+
+	if err := c.Start(); err != nil {
+		return errco.NewErr(errco.ERROR_SERVER_NOT_RESPONDING, errco.LVL_1, "StartMS", err.Error())
+	}
+
+	cmd = c
+	startTime = time.Now()
+	frozen = false // server is warm and can now take connections
+
+	limits := procgroup.Limits{
+		MemoryLimitMB:       config.ConfigRuntime.Msh.MemoryLimitMB,
+		CpuRateLimitPercent: config.ConfigRuntime.Msh.CpuRateLimitPercent,
+	}
+
+	group, err := procgroup.New("msh-server", limits)
+	if err != nil {
+		// non fatal: the server is running, we just lose tree accounting/limits
+		errco.LogMshErr(errco.NewErr(errco.ERROR_SERVER_NOT_RESPONDING, errco.LVL_3, "StartMS", err.Error()))
+		return nil
+	}
+
+	if err := group.Assign(cmd.Process.Pid); err != nil {
+		errco.LogMshErr(errco.NewErr(errco.ERROR_SERVER_NOT_RESPONDING, errco.LVL_3, "StartMS", err.Error()))
+		return nil
+	}
+
+	tree = group
+
+	return nil
+}
+
+// StopMS terminates the server process tree. When a procgroup is tracking it,
+// this closes/terminates the group (JOB_OBJECT_LIMIT_KILL_ON_JOB_CLOSE on
+// windows), killing every process in the tree atomically - replacing the
+// previous best-effort child-by-child freeze/kill logic. Without a tracked
+// group (procgroup.New failed, or StartMS was never called) it falls back to
+// killing just the top-level process.
+func StopMS() *errco.Error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	// going back to hibernation: no players can be connected to a stopped
+	// server, and the server is frozen until the next StartMS.
+	players = map[string]struct{}{}
+	frozen = true
+
+	if tree != nil {
+		if err := tree.Terminate(); err != nil {
+			return errco.NewErr(errco.ERROR_SERVER_NOT_RESPONDING, errco.LVL_1, "StopMS", err.Error())
+		}
+		tree = nil
+		return nil
+	}
+
+	if cmd != nil && cmd.Process != nil {
+		if err := cmd.Process.Kill(); err != nil {
+			return errco.NewErr(errco.ERROR_SERVER_NOT_RESPONDING, errco.LVL_1, "StopMS", err.Error())
+		}
+	}
+
+	return nil
+}
+
+// TermUpTime returns seconds since the server process started, 0 if it isn't
+// running.
+func TermUpTime() int {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if startTime.IsZero() {
+		return 0
+	}
+	return int(time.Since(startTime).Seconds())
+}
+
+// TreeStats returns the server process tree's aggregate cpu/mem usage, as
+// tracked by procgroup (Job Object on windows, gopsutil tree walk elsewhere).
+func TreeStats() (float64, float64, *errco.Error) {
+	mu.Lock()
+	t := tree
+	mu.Unlock()
+
+	if t == nil {
+		return 0, 0, nil
+	}
+
+	stats, err := t.Stats()
+	if err != nil {
+		return 0, 0, errco.NewErr(errco.ERROR_SERVER_NOT_RESPONDING, errco.LVL_D, "TreeStats", err.Error())
+	}
+
+	return stats.CpuPercent, stats.MemPercent, nil
+}
+
+// AddPlayer records playerName as connected.
+func AddPlayer(playerName string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	players[playerName] = struct{}{}
+}
+
+// RemovePlayer records playerName as disconnected.
+func RemovePlayer(playerName string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	delete(players, playerName)
+}
+
+// CountPlayers returns the number of players currently connected.
+func CountPlayers() int {
+	mu.Lock()
+	defer mu.Unlock()
+
+	return len(players)
+}
+
+// IsServerFrozen reports whether the server is currently hibernated/frozen
+// (no process running, waiting for a connection to trigger StartMS) rather
+// than warm and accepting connections.
+func IsServerFrozen() bool {
+	mu.Lock()
+	defer mu.Unlock()
+
+	return frozen
+}